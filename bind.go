@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var bindTimeout = flag.Duration("bind-timeout", 30*time.Second, "how long a PodGroup member waits in the permit stage for the rest of its group before the bind is rejected")
+
+// bindRequest is one pod's half-finished Bind call, parked in a waitGroup
+// until the rest of its PodGroup is ready to go.
+type bindRequest struct {
+	podName      string
+	podNamespace string
+	podUID       types.UID
+	node         string
+	done         chan error
+}
+
+// waitGroup collects the bindRequests for one PodGroup until minMember of
+// them have arrived, then releases all of them at once.
+type waitGroup struct {
+	minMember int
+	requests  []*bindRequest
+	timer     *time.Timer
+}
+
+// bindPermitArea is the in-memory permit stage: pods whose PodGroup hasn't
+// reached minMember yet sit here instead of being bound immediately. This
+// mirrors the permit/wait-for-gang step of scheduler-plugins coscheduling,
+// but implemented inside the extender rather than as a scheduler plugin.
+type bindPermitArea struct {
+	mu     sync.Mutex
+	groups map[string]*waitGroup
+}
+
+var permitArea = &bindPermitArea{groups: map[string]*waitGroup{}}
+
+func waitingGroupCount() int {
+	return permitArea.size()
+}
+
+// size returns the number of PodGroups currently parked in a, under a.mu so
+// it's safe to call while admit/timeout/release are running concurrently.
+func (a *bindPermitArea) size() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.groups)
+}
+
+// admit parks req under group until minMember requests have accumulated,
+// then releases the whole batch. If the timeout elapses first, every
+// parked request (including req) is rejected.
+func (a *bindPermitArea) admit(group string, minMember int, req *bindRequest) {
+	a.mu.Lock()
+	wg, ok := a.groups[group]
+	if !ok {
+		wg = &waitGroup{minMember: minMember}
+		wg.timer = time.AfterFunc(*bindTimeout, func() { a.timeout(group) })
+		a.groups[group] = wg
+	}
+	wg.requests = append(wg.requests, req)
+	ready := len(wg.requests) >= wg.minMember
+	if ready {
+		delete(a.groups, group)
+		wg.timer.Stop()
+	}
+	a.mu.Unlock()
+
+	if ready {
+		a.release(wg.requests)
+	}
+}
+
+func (a *bindPermitArea) timeout(group string) {
+	a.mu.Lock()
+	wg, ok := a.groups[group]
+	if ok {
+		delete(a.groups, group)
+	}
+	a.mu.Unlock()
+	if !ok {
+		return
+	}
+	for _, req := range wg.requests {
+		req.done <- fmt.Errorf("timed out waiting for pod group to reach minMember=%d (got %d)", wg.minMember, len(wg.requests))
+	}
+}
+
+func (a *bindPermitArea) release(requests []*bindRequest) {
+	for _, req := range requests {
+		err := doBind(req.podName, req.podNamespace, req.podUID, req.node)
+		req.done <- err
+	}
+}
+
+// doBind commits the binding decision with the apiserver.
+func doBind(podName, podNamespace string, podUID types.UID, node string) error {
+	binding := &v1.Binding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: podNamespace, Name: podName, UID: podUID},
+		Target:     v1.ObjectReference{Kind: "Node", Name: node},
+	}
+	return clientSet.CoreV1().Pods(podNamespace).Bind(binding)
+}
+
+// PodGroupBind is a real Bind implementation: pods outside a PodGroup are
+// bound immediately, pods inside one wait in the permit area until the
+// whole group can be released together (or the bind times out).
+var PodGroupBind = Bind{
+	Func: func(ctx context.Context, podName string, podNamespace string, podUID types.UID, node string) error {
+		pod, err := clientSet.CoreV1().Pods(podNamespace).Get(podName, metav1.GetOptions{})
+		if err != nil {
+			LoggerFrom(ctx).Error(err, "failed to get pod for bind, binding without pod-group admission", "podName", podName, "podNamespace", podNamespace)
+			return doBind(podName, podNamespace, podUID, node)
+		}
+
+		group, minMember, ok := podGroupOf(*pod)
+		if !ok {
+			return doBind(podName, podNamespace, podUID, node)
+		}
+
+		req := &bindRequest{podName: podName, podNamespace: podNamespace, podUID: podUID, node: node, done: make(chan error, 1)}
+		permitArea.admit(group, minMember, req)
+		return <-req.done
+	},
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
+)
+
+// TestBindPermitAreaHoldsUntilMinMember exercises the /scheduler/bind permit
+// stage directly: a single request for a two-member group must stay parked
+// (not released, group still waiting) until a second member arrives, without
+// touching the apiserver.
+func TestBindPermitAreaHoldsUntilMinMember(t *testing.T) {
+	area := &bindPermitArea{groups: map[string]*waitGroup{}}
+	group := "test-group"
+
+	first := &bindRequest{podName: "pod-a", podNamespace: "default", podUID: types.UID("a"), node: "node-1", done: make(chan error, 1)}
+	area.admit(group, 2, first)
+
+	select {
+	case err := <-first.done:
+		t.Fatalf("first request released before minMember reached: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if got := area.size(); got != 1 {
+		t.Fatalf("expected 1 waiting group, got %d", got)
+	}
+}
+
+func TestBindPermitAreaTimesOutIncompleteGroup(t *testing.T) {
+	oldTimeout := *bindTimeout
+	*bindTimeout = 10 * time.Millisecond
+	defer func() { *bindTimeout = oldTimeout }()
+
+	area := &bindPermitArea{groups: map[string]*waitGroup{}}
+	req := &bindRequest{podName: "pod-a", podNamespace: "default", podUID: types.UID("a"), node: "node-1", done: make(chan error, 1)}
+	area.admit("test-group", 2, req)
+
+	select {
+	case err := <-req.done:
+		if err == nil {
+			t.Fatal("expected timeout error, got nil")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for permit area to reject incomplete group")
+	}
+}
+
+// TestBindHandlerReleasesGroupOnMinMember drives the real /scheduler/bind
+// HTTP endpoint end to end: two pods sharing a two-member PodGroup are
+// bound, through bindHandler -> PodGroupBind -> the permit area, and the
+// fake clientset should see both Pods get a Binding once the second
+// request lands.
+func TestBindHandlerReleasesGroupOnMinMember(t *testing.T) {
+	const group = "handler-test-group"
+	pods := []*v1.Pod{
+		newPodGroupMember("pod-a", group, 2),
+		newPodGroupMember("pod-b", group, 2),
+	}
+
+	oldClientSet := clientSet
+	oldPermitArea := permitArea
+	fakeClientSet := fake.NewSimpleClientset()
+	for _, pod := range pods {
+		if _, err := fakeClientSet.CoreV1().Pods(pod.Namespace).Create(pod); err != nil {
+			t.Fatalf("failed to seed fake pod %s: %v", pod.Name, err)
+		}
+	}
+	clientSet = fakeClientSet
+	permitArea = &bindPermitArea{groups: map[string]*waitGroup{}}
+	defer func() { clientSet = oldClientSet; permitArea = oldPermitArea }()
+
+	router := httprouter.New()
+	AddBind(router, PodGroupBind)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	type bindResult struct {
+		result schedulerapi.ExtenderBindingResult
+		err    error
+	}
+	results := make(chan bindResult, len(pods))
+	for _, pod := range pods {
+		pod := pod
+		go func() {
+			res, err := postBind(server.URL, pod)
+			results <- bindResult{result: res, err: err}
+		}()
+	}
+
+	for i := 0; i < len(pods); i++ {
+		select {
+		case r := <-results:
+			if r.err != nil {
+				t.Fatalf("bind request failed: %v", r.err)
+			}
+			if r.result.Error != "" {
+				t.Fatalf("unexpected bind error in response: %s", r.result.Error)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for both bind requests to be released")
+		}
+	}
+
+	bound := map[string]bool{}
+	for _, action := range fakeClientSet.Actions() {
+		if action.GetVerb() != "create" || action.GetSubresource() != "binding" {
+			continue
+		}
+		bound[action.(clienttesting.CreateAction).GetObject().(*v1.Binding).Name] = true
+	}
+	for _, pod := range pods {
+		if !bound[pod.Name] {
+			t.Fatalf("expected pod %s to have been bound, actions seen: %v", pod.Name, fakeClientSet.Actions())
+		}
+	}
+}
+
+func newPodGroupMember(name, group string, minMember int) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID(name),
+			Labels: map[string]string{
+				podGroupNameLabel:      group,
+				podGroupMinMemberLabel: strconv.Itoa(minMember),
+			},
+		},
+	}
+}
+
+func postBind(serverURL string, pod *v1.Pod) (schedulerapi.ExtenderBindingResult, error) {
+	args := schedulerapi.ExtenderBindingArgs{
+		PodName:      pod.Name,
+		PodNamespace: pod.Namespace,
+		PodUID:       pod.UID,
+		Node:         "node-1",
+	}
+	body, err := json.Marshal(args)
+	if err != nil {
+		return schedulerapi.ExtenderBindingResult{}, err
+	}
+
+	resp, err := http.Post(serverURL+bindPath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return schedulerapi.ExtenderBindingResult{}, err
+	}
+	defer resp.Body.Close()
+
+	var result schedulerapi.ExtenderBindingResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return schedulerapi.ExtenderBindingResult{}, err
+	}
+	return result, nil
+}
@@ -0,0 +1,166 @@
+// Package cache maintains a precomputed, copy-on-write view of cluster
+// resource usage so prioritizers can read a single atomic snapshot instead
+// of re-scanning the pod indexer on every /scheduler/priorities request.
+// It is intentionally modeled after the upstream scheduler's SharedLister:
+// writers (informer event handlers) mutate a fresh copy of the node map and
+// swap it in atomically; readers always see a consistent, immutable
+// snapshot taken at the start of their request.
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"k8s.io/api/core/v1"
+)
+
+// NodeInfo is the precomputed resource accounting for one node.
+type NodeInfo struct {
+	RequestedCPU   int64
+	RequestedMem   int64
+	AllocatableCPU int64
+	AllocatableMem int64
+}
+
+// Snapshot is an immutable, point-in-time view of every known node. Once
+// published it is never mutated, so concurrent readers need no locking.
+type Snapshot struct {
+	nodes map[string]*NodeInfo
+}
+
+// NodeInfo returns the cached info for node, or (nil, false) if the node
+// has not been observed yet.
+func (s *Snapshot) NodeInfo(node string) (*NodeInfo, bool) {
+	info, ok := s.nodes[node]
+	return info, ok
+}
+
+// SharedLister is a copy-on-write store of per-node resource accounting,
+// kept current by pod/node informer event handlers and read by
+// prioritizers via an atomically-swapped Snapshot.
+type SharedLister struct {
+	current atomic.Value // holds *Snapshot
+
+	// mu serializes writers so read-modify-swap of the node map can't race
+	// with itself; readers never take mu.
+	mu sync.Mutex
+}
+
+// NewSharedLister returns a SharedLister with an empty initial snapshot.
+func NewSharedLister() *SharedLister {
+	l := &SharedLister{}
+	l.current.Store(&Snapshot{nodes: map[string]*NodeInfo{}})
+	return l
+}
+
+// Snapshot returns the most recently published snapshot. Safe to call
+// concurrently with writers; the returned value never changes underneath
+// the caller.
+func (l *SharedLister) Snapshot() *Snapshot {
+	return l.current.Load().(*Snapshot)
+}
+
+// clone returns a copy of the current node map, with every NodeInfo copied
+// as well, so a writer can mutate entries without disturbing snapshots
+// already handed out to readers.
+func (l *SharedLister) clone() map[string]*NodeInfo {
+	old := l.Snapshot().nodes
+	next := make(map[string]*NodeInfo, len(old))
+	for name, info := range old {
+		copied := *info
+		next[name] = &copied
+	}
+	return next
+}
+
+func (l *SharedLister) entry(nodes map[string]*NodeInfo, name string) *NodeInfo {
+	info, ok := nodes[name]
+	if !ok {
+		info = &NodeInfo{}
+		nodes[name] = info
+	}
+	return info
+}
+
+func podRequests(pod *v1.Pod) (cpu int64, mem int64) {
+	for _, container := range pod.Spec.Containers {
+		cpu += container.Resources.Requests.Cpu().MilliValue()
+		mem += container.Resources.Requests.Memory().Value()
+	}
+	return cpu, mem
+}
+
+// isAssigned reports whether pod occupies resources on a node.
+func isAssigned(pod *v1.Pod) bool {
+	return len(pod.Spec.NodeName) > 0 && pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed
+}
+
+// AddPod folds pod's requested resources into its node's entry, if any.
+func (l *SharedLister) AddPod(pod *v1.Pod) {
+	if !isAssigned(pod) {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	nodes := l.clone()
+	cpu, mem := podRequests(pod)
+	info := l.entry(nodes, pod.Spec.NodeName)
+	info.RequestedCPU += cpu
+	info.RequestedMem += mem
+	l.current.Store(&Snapshot{nodes: nodes})
+}
+
+// UpdatePod replaces oldPod's contribution with newPod's.
+func (l *SharedLister) UpdatePod(oldPod, newPod *v1.Pod) {
+	l.mu.Lock()
+	nodes := l.clone()
+	if isAssigned(oldPod) {
+		cpu, mem := podRequests(oldPod)
+		info := l.entry(nodes, oldPod.Spec.NodeName)
+		info.RequestedCPU -= cpu
+		info.RequestedMem -= mem
+	}
+	if isAssigned(newPod) {
+		cpu, mem := podRequests(newPod)
+		info := l.entry(nodes, newPod.Spec.NodeName)
+		info.RequestedCPU += cpu
+		info.RequestedMem += mem
+	}
+	l.current.Store(&Snapshot{nodes: nodes})
+	l.mu.Unlock()
+}
+
+// DeletePod removes pod's contribution from its node's entry.
+func (l *SharedLister) DeletePod(pod *v1.Pod) {
+	if !isAssigned(pod) {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	nodes := l.clone()
+	cpu, mem := podRequests(pod)
+	info := l.entry(nodes, pod.Spec.NodeName)
+	info.RequestedCPU -= cpu
+	info.RequestedMem -= mem
+	l.current.Store(&Snapshot{nodes: nodes})
+}
+
+// SetNode records node's allocatable capacity.
+func (l *SharedLister) SetNode(node *v1.Node) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	nodes := l.clone()
+	info := l.entry(nodes, node.Name)
+	info.AllocatableCPU = node.Status.Allocatable.Cpu().MilliValue()
+	info.AllocatableMem = node.Status.Allocatable.Memory().Value()
+	l.current.Store(&Snapshot{nodes: nodes})
+}
+
+// DeleteNode drops node from the snapshot entirely.
+func (l *SharedLister) DeleteNode(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	nodes := l.clone()
+	delete(nodes, name)
+	l.current.Store(&Snapshot{nodes: nodes})
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/tools/cache"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
+)
+
+const (
+	// podGroupNameLabel and podGroupMinMemberLabel mirror the labels used by the
+	// PodGroup CRD in scheduler-plugins/Volcano so manifests are portable.
+	podGroupNameLabel       = "pod-group.scheduling.sigs.k8s.io/name"
+	podGroupMinMemberLabel  = "pod-group.scheduling.sigs.k8s.io/min-available"
+	podGroupStateTTL        = 10 * time.Minute
+	podGroupGCInterval      = time.Minute
+
+	// groupCountCacheTTL bounds how long a cached membership count is
+	// trusted before groupTracker recomputes it from the indexers. A
+	// single scheduling cycle calls PodGroupGang once per candidate node,
+	// so this is what turns an O(nodes) indexer scan into one scan per
+	// cycle.
+	groupCountCacheTTL = 2 * time.Second
+)
+
+// podGroupState tracks what we currently know about a PodGroup: its declared
+// minMember, the last computed membership count (bound+pending) and when
+// that count was taken, and when we last saw activity for it at all.
+// Entries are garbage collected once they go stale so long-lived extender
+// processes don't leak memory for PodGroups that never reach minMember or
+// finished long ago.
+type podGroupState struct {
+	minMember int
+	lastSeen  time.Time
+	total     int
+	countedAt time.Time
+}
+
+// podGroupTracker caches per-group membership counts for the gang predicate
+// and priority below, so a burst of predicate calls for the same
+// scheduling cycle (one per candidate node) rescans the pod indexers once
+// instead of once per node. It is GC'd on a timer.
+type podGroupTracker struct {
+	mu     sync.Mutex
+	groups map[string]*podGroupState
+}
+
+func newPodGroupTracker() *podGroupTracker {
+	t := &podGroupTracker{groups: map[string]*podGroupState{}}
+	go t.gcLoop()
+	return t
+}
+
+func (t *podGroupTracker) touch(name string, minMember int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state, ok := t.groups[name]
+	if !ok {
+		state = &podGroupState{}
+		t.groups[name] = state
+	}
+	state.minMember = minMember
+	state.lastSeen = time.Now()
+}
+
+// total returns the cached membership count for name if it was computed
+// within groupCountCacheTTL, otherwise calls compute, caches, and returns
+// the fresh result.
+func (t *podGroupTracker) total(name string, compute func() int) int {
+	t.mu.Lock()
+	if state, ok := t.groups[name]; ok && time.Since(state.countedAt) < groupCountCacheTTL {
+		total := state.total
+		t.mu.Unlock()
+		return total
+	}
+	t.mu.Unlock()
+
+	total := compute()
+
+	t.mu.Lock()
+	state, ok := t.groups[name]
+	if !ok {
+		state = &podGroupState{}
+		t.groups[name] = state
+	}
+	state.total = total
+	state.countedAt = time.Now()
+	t.mu.Unlock()
+	return total
+}
+
+func (t *podGroupTracker) gcLoop() {
+	for range time.Tick(podGroupGCInterval) {
+		t.mu.Lock()
+		for name, state := range t.groups {
+			if time.Since(state.lastSeen) > podGroupStateTTL {
+				delete(t.groups, name)
+			}
+		}
+		t.mu.Unlock()
+	}
+}
+
+var groupTracker = newPodGroupTracker()
+
+// pendingPodListWatcher/pendingIndexer track pods that have not yet been
+// bound to a node, indexed by PodGroup name, so the gang predicate can see
+// how many siblings are still waiting without re-listing the apiserver on
+// every request.
+var (
+	pendingPodListWatcher          = cache.NewListWatchFromClient(clientSet.CoreV1().RESTClient(), "pods", v1.NamespaceAll, fields.OneTermEqualSelector("spec.nodeName", ""))
+	pendingIndexer, pendingInformer = cache.NewIndexerInformer(pendingPodListWatcher,
+		&v1.Pod{},
+		time.Hour*24,
+		cache.ResourceEventHandlerFuncs{},
+		cache.Indexers{"group": indexByPodGroupName})
+)
+
+func indexByPodGroupName(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return []string{}, nil
+	}
+	group, ok := pod.Labels[podGroupNameLabel]
+	if !ok {
+		return []string{}, nil
+	}
+	return []string{group}, nil
+}
+
+// podGroupOf returns the group name and declared minMember for pod, and
+// false if pod does not belong to a PodGroup.
+func podGroupOf(pod v1.Pod) (string, int, bool) {
+	group, ok := pod.Labels[podGroupNameLabel]
+	if !ok || group == "" {
+		return "", 0, false
+	}
+	minMember, err := strconv.Atoi(pod.Labels[podGroupMinMemberLabel])
+	if err != nil || minMember <= 0 {
+		minMember = 1
+	}
+	return group, minMember, true
+}
+
+// boundGroupSize counts pods already assigned a node that belong to group.
+// indexer holds every pod regardless of scheduling state, so pods with no
+// Spec.NodeName yet are skipped here — they are pendingGroupSize's job —
+// otherwise a pending member would be counted by both and the combined
+// total would overstate the group's real size.
+func boundGroupSize(group string) int {
+	count := 0
+	for _, obj := range indexer.List() {
+		if pod, ok := obj.(*v1.Pod); ok && len(pod.Spec.NodeName) > 0 && pod.Labels[podGroupNameLabel] == group {
+			count++
+		}
+	}
+	return count
+}
+
+// pendingGroupSize counts unscheduled pods that belong to group.
+func pendingGroupSize(ctx context.Context, group string) int {
+	pods, err := pendingIndexer.ByIndex("group", group)
+	if err != nil {
+		LoggerFrom(ctx).Error(err, "failed to list pending group members", "group", group)
+		return 0
+	}
+	return len(pods)
+}
+
+// PodGroupGang rejects scheduling a PodGroup member until enough of its
+// siblings (bound or still pending) exist to ever reach minMember. This
+// keeps a partial gang from occupying nodes it cannot make productive use
+// of; the member is left unschedulable and retried by the scheduler until
+// the rest of the group shows up.
+var PodGroupGang = Predicate{
+	Name: "pod_group_gang",
+	Func: func(ctx context.Context, pod v1.Pod, node v1.Node) (bool, error) {
+		group, minMember, ok := podGroupOf(pod)
+		if !ok {
+			return true, nil
+		}
+		groupTracker.touch(group, minMember)
+
+		total := groupTracker.total(group, func() int {
+			return boundGroupSize(group) + pendingGroupSize(ctx, group)
+		})
+		if total < minMember {
+			LoggerFrom(ctx).Info("holding pod group member, minMember not yet reachable", "group", group, "have", total, "minMember", minMember)
+			return false, nil
+		}
+		return true, nil
+	},
+}
+
+// groupMembersOnNode counts how many bound members of group already sit on
+// node, used by PodGroupAffinityPriority to keep a gang co-located.
+func groupMembersOnNode(ctx context.Context, group, nodeName string) int {
+	pods, err := indexer.ByIndex("node", nodeName)
+	if err != nil {
+		LoggerFrom(ctx).Error(err, "failed to list pods on node", "group", group, "node", nodeName)
+		return 0
+	}
+	count := 0
+	for _, obj := range pods {
+		if p, ok := obj.(*v1.Pod); ok && p.Labels[podGroupNameLabel] == group {
+			count++
+		}
+	}
+	return count
+}
+
+// PodGroupAffinityPriority boosts nodes that already host other members of
+// the pod's PodGroup so the group tends to land close together, reducing
+// cross-node traffic for batch/gang workloads.
+var PodGroupAffinityPriority = Prioritize{
+	Name: "pod_group_affinity",
+	Func: func(ctx context.Context, pod v1.Pod, nodes []v1.Node) (*schedulerapi.HostPriorityList, error) {
+		priorityList := make(schedulerapi.HostPriorityList, len(nodes))
+
+		group, _, ok := podGroupOf(pod)
+		for i, node := range nodes {
+			priorityList[i] = schedulerapi.HostPriority{Host: node.Name, Score: 0}
+			if !ok {
+				continue
+			}
+			members := groupMembersOnNode(ctx, group, node.Name)
+			if members > 10 {
+				members = 10
+			}
+			priorityList[i].Score = int64(members) * 10
+		}
+		return &priorityList, nil
+	},
+}
+
+func init() {
+	RegisterPredicate(PodGroupGang)
+	RegisterPrioritize(PodGroupAffinityPriority)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Level orders log verbosity the same way the colog levels this package
+// used to depend on did, so LOG_LEVEL keeps its existing meaning.
+type Level int
+
+const (
+	LTrace Level = iota
+	LDebug
+	LInfo
+	LWarning
+	LError
+	LAlert
+)
+
+func (l Level) String() string {
+	switch l {
+	case LTrace:
+		return "TRACE"
+	case LDebug:
+		return "DEBUG"
+	case LWarning:
+		return "WARNING"
+	case LError:
+		return "ERROR"
+	case LAlert:
+		return "ALERT"
+	default:
+		return "INFO"
+	}
+}
+
+func StringToLevel(levelStr string) Level {
+	switch level := strings.ToUpper(levelStr); level {
+	case "TRACE":
+		return LTrace
+	case "DEBUG":
+		return LDebug
+	case "INFO":
+		return LInfo
+	case "WARNING":
+		return LWarning
+	case "ERROR":
+		return LError
+	case "ALERT":
+		return LAlert
+	case "":
+		return LInfo
+	default:
+		fmt.Fprintf(os.Stderr, "warning: LOG_LEVEL=\"%s\" is invalid, falling back to \"INFO\".\n", level)
+		return LInfo
+	}
+}
+
+// minLevel is the process-wide log threshold, set once from LOG_LEVEL at
+// startup. It's an int32 so Logger.emit can read it without locking.
+var minLevel int32
+
+func SetMinLevel(l Level) {
+	atomic.StoreInt32(&minLevel, int32(l))
+}
+
+// Logger is a minimal klog/logr-style structured logger: immutable,
+// accumulates key/value pairs via WithValues, and emits one JSON line per
+// Info/Error call so every predicate/prioritizer/bind log for a single
+// scheduling cycle can be grepped together by its request-id.
+type Logger struct {
+	values []interface{}
+}
+
+func NewLogger() Logger {
+	return Logger{}
+}
+
+// WithValues returns a new Logger carrying kv appended to the existing
+// key/value pairs. Used to decorate a request's logger with pod
+// namespace/name/UID and a request-id once, up front.
+func (l Logger) WithValues(kv ...interface{}) Logger {
+	next := make([]interface{}, 0, len(l.values)+len(kv))
+	next = append(next, l.values...)
+	next = append(next, kv...)
+	return Logger{values: next}
+}
+
+func (l Logger) Info(msg string, kv ...interface{}) {
+	l.emit(LInfo, msg, nil, kv)
+}
+
+func (l Logger) Error(err error, msg string, kv ...interface{}) {
+	l.emit(LError, msg, err, kv)
+}
+
+func (l Logger) emit(level Level, msg string, err error, extra []interface{}) {
+	if level < Level(atomic.LoadInt32(&minLevel)) {
+		return
+	}
+
+	fields := make(map[string]interface{}, (len(l.values)+len(extra))/2)
+	for i := 0; i+1 < len(l.values); i += 2 {
+		fields[fmt.Sprint(l.values[i])] = l.values[i+1]
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		fields[fmt.Sprint(extra[i])] = extra[i+1]
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+
+	line, marshalErr := json.Marshal(struct {
+		Time   string                 `json:"time"`
+		Level  string                 `json:"level"`
+		Msg    string                 `json:"msg"`
+		Fields map[string]interface{} `json:"fields,omitempty"`
+	}{
+		Time:   time.Now().Format(time.RFC3339),
+		Level:  level.String(),
+		Msg:    msg,
+		Fields: fields,
+	})
+	if marshalErr != nil {
+		fmt.Fprintln(os.Stderr, marshalErr)
+		return
+	}
+	fmt.Fprintln(os.Stderr, string(line))
+}
+
+type loggerContextKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger, retrievable via
+// LoggerFrom.
+func WithLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// LoggerFrom returns the Logger stashed in ctx by WithLogger, or a bare
+// Logger with no accumulated fields if none was stashed.
+func LoggerFrom(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(Logger); ok {
+		return logger
+	}
+	return NewLogger()
+}
+
+// requestIDHeader lets a caller (or an upstream proxy) supply its own
+// correlation id; when absent we mint one so a single scheduling cycle's
+// predicate/prioritize/bind calls can still be tied together.
+const requestIDHeader = "X-Request-Id"
+
+func newRequestID() string {
+	return uuid.New().String()
+}
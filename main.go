@@ -1,14 +1,14 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 
-	"github.com/comail/colog"
 	"github.com/julienschmidt/httprouter"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/fields"
@@ -20,6 +20,8 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
+
+	nodecache "github.com/u2takey/k8s-scheduler-extender-example/cache"
 )
 
 const (
@@ -34,25 +36,60 @@ const (
 var (
 	version string // injected via ldflags at build time
 
-	config, _         = rest.InClusterConfig()
-	clientSet         = kubernetes.NewForConfigOrDie(config)
-	podListWatcher    = cache.NewListWatchFromClient(clientSet.CoreV1().RESTClient(), "pods", v1.NamespaceAll, fields.Everything())
+	config, _ = rest.InClusterConfig()
+
+	// clientSet is typed as the kubernetes.Interface rather than the
+	// concrete *kubernetes.Clientset so tests can swap in
+	// client-go/kubernetes/fake for handler-level coverage without a
+	// real apiserver.
+	clientSet      kubernetes.Interface = kubernetes.NewForConfigOrDie(config)
+	podListWatcher                     = cache.NewListWatchFromClient(clientSet.CoreV1().RESTClient(), "pods", v1.NamespaceAll, fields.Everything())
 	indexer, informer = cache.NewIndexerInformer(podListWatcher,
 		&v1.Pod{},
 		time.Hour*24,
-		cache.ResourceEventHandlerFuncs{},
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { nodeLister.AddPod(obj.(*v1.Pod)) },
+			UpdateFunc: func(old, new interface{}) { nodeLister.UpdatePod(old.(*v1.Pod), new.(*v1.Pod)) },
+			DeleteFunc: func(obj interface{}) {
+				if pod, ok := podFromDeleteEvent(obj); ok {
+					nodeLister.DeletePod(pod)
+				}
+			},
+		},
 		cache.Indexers{"node": indexByPodNodeName})
 
+	// nodeLister is the copy-on-write snapshot of per-node resource usage,
+	// kept current by the pod informer event handlers above and the node
+	// informer event handlers below, and read
+	// by prioritizers via nodeLister.Snapshot().
+	nodeLister = nodecache.NewSharedLister()
+
+	nodeListWatcher       = cache.NewListWatchFromClient(clientSet.CoreV1().RESTClient(), "nodes", v1.NamespaceAll, fields.Everything())
+	nodeIndexer, nodeInformer = cache.NewIndexerInformer(nodeListWatcher,
+		&v1.Node{},
+		time.Hour*24,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { nodeLister.SetNode(obj.(*v1.Node)) },
+			UpdateFunc: func(old, new interface{}) { nodeLister.SetNode(new.(*v1.Node)) },
+			DeleteFunc: func(obj interface{}) {
+				if node, ok := nodeFromDeleteEvent(obj); ok {
+					nodeLister.DeleteNode(node.Name)
+				}
+			},
+		},
+		cache.Indexers{})
+
 	TruePredicate = Predicate{
 		Name: "always_true",
-		Func: func(pod v1.Pod, node v1.Node) (bool, error) {
+		Func: func(ctx context.Context, pod v1.Pod, node v1.Node) (bool, error) {
 			return true, nil
 		},
 	}
 
 	GroupPriority = Prioritize{
 		Name: "group_score",
-		Func: func(_ v1.Pod, nodes []v1.Node) (*schedulerapi.HostPriorityList, error) {
+		Func: func(ctx context.Context, _ v1.Pod, nodes []v1.Node) (*schedulerapi.HostPriorityList, error) {
+			logger := LoggerFrom(ctx)
 			var priorityList schedulerapi.HostPriorityList
 			priorityList = make([]schedulerapi.HostPriority, len(nodes))
 
@@ -62,48 +99,84 @@ var (
 					Score: 1000,
 				}
 
-				if group, ok := node.Labels["group"]; ok && group == "Scale" {
+				if group, ok := node.Labels["group"]; ok && group == scaleGroupLabelValue() {
 					// Details: (cpu(10 * sum(requested) / capacity) + memory(10 * sum(requested) / capacity)) / 2
-					pods, err := indexer.ByIndex("node", node.Name)
-					if err != nil{
+					// Read from the precomputed snapshot instead of rescanning the
+					// pod indexer on every priority request.
+					info, ok := nodeLister.Snapshot().NodeInfo(node.Name)
+					if !ok {
 						priorityList[i].Score = 0
-						log.Println(err)
 						continue
 					}
-					cpu, mem:= &resource.Quantity{}, &resource.Quantity{}
-					for _, obj := range pods{
-						if pod, ok := obj.(*v1.Pod); ok{
-							for _, container := range pod.Spec.Containers{
-								cpu.Add(*container.Resources.Requests.Cpu())
-								mem.Add(*container.Resources.Requests.Memory())
-							}
-						}else{
-							log.Println("not pod")
-						}
-					}
-					nodeCpu, nodeMem := node.Status.Capacity.Cpu(), node.Status.Capacity.Memory()
-					score := (toFloat(cpu)/toFloat(nodeCpu) + toFloat(mem)/toFloat(nodeMem))* 100.0
+					nodeCpu, nodeMem := node.Status.Capacity.Cpu().MilliValue(), node.Status.Capacity.Memory().Value()
+					score := (float64(info.RequestedCPU)/float64(nodeCpu) + float64(info.RequestedMem)/float64(nodeMem)) * 100.0
 					priorityList[i].Score = int64(score)
 				}
-				log.Printf("score for %s %d\n", node.Name, priorityList[i].Score)
+				logger.Info("scored node", "node", node.Name, "score", priorityList[i].Score)
 			}
 			return &priorityList, nil
 		},
 	}
 
 	NoBind = Bind{
-		Func: func(podName string, podNamespace string, podUID types.UID, node string) error {
+		Func: func(ctx context.Context, podName string, podNamespace string, podUID types.UID, node string) error {
 			return fmt.Errorf("This extender doesn't support Bind.  Please make 'BindVerb' be empty in your ExtenderConfig.")
 		},
 	}
 
 )
 
+func init() {
+	RegisterPredicate(TruePredicate)
+	RegisterPrioritize(GroupPriority)
+}
+
 func toFloat(q *resource.Quantity) float64{
 	a, _ := q.AsInt64()
 	return float64(a)
 }
 
+// podFromDeleteEvent unwraps obj into a *v1.Pod, handling the
+// cache.DeletedFinalStateUnknown tombstone that client-go delivers instead
+// of the real object when a delete is observed via relist rather than a
+// watch event. A DeleteFunc that skips this and asserts obj.(*v1.Pod)
+// directly will panic the first time that happens.
+func podFromDeleteEvent(obj interface{}) (*v1.Pod, bool) {
+	if pod, ok := obj.(*v1.Pod); ok {
+		return pod, true
+	}
+	tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+	if !ok {
+		log.Printf("error: unexpected object type in pod delete event: %T", obj)
+		return nil, false
+	}
+	pod, ok := tombstone.Obj.(*v1.Pod)
+	if !ok {
+		log.Printf("error: tombstone contained unexpected object type: %T", tombstone.Obj)
+		return nil, false
+	}
+	return pod, true
+}
+
+// nodeFromDeleteEvent is podFromDeleteEvent's node-informer counterpart;
+// see podFromDeleteEvent for why the tombstone case has to be handled.
+func nodeFromDeleteEvent(obj interface{}) (*v1.Node, bool) {
+	if node, ok := obj.(*v1.Node); ok {
+		return node, true
+	}
+	tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+	if !ok {
+		log.Printf("error: unexpected object type in node delete event: %T", obj)
+		return nil, false
+	}
+	node, ok := tombstone.Obj.(*v1.Node)
+	if !ok {
+		log.Printf("error: tombstone contained unexpected object type: %T", tombstone.Obj)
+		return nil, false
+	}
+	return node, true
+}
+
 func indexByPodNodeName(obj interface{}) ([]string, error) {
 	pod, ok := obj.(*v1.Pod)
 	if !ok {
@@ -116,55 +189,41 @@ func indexByPodNodeName(obj interface{}) ([]string, error) {
 	return []string{pod.Spec.NodeName}, nil
 }
 
-func StringToLevel(levelStr string) colog.Level {
-	switch level := strings.ToUpper(levelStr); level {
-	case "TRACE":
-		return colog.LTrace
-	case "DEBUG":
-		return colog.LDebug
-	case "INFO":
-		return colog.LInfo
-	case "WARNING":
-		return colog.LWarning
-	case "ERROR":
-		return colog.LError
-	case "ALERT":
-		return colog.LAlert
-	default:
-		log.Printf("warning: LOG_LEVEL=\"%s\" is empty or invalid, fallling back to \"INFO\".\n", level)
-		return colog.LInfo
+func main() {
+	flag.Parse()
+	if err := loadPolicy(*policyConfigFile); err != nil {
+		log.Fatalf("error loading policy config: %v", err)
 	}
-}
+	watchPolicyReload(*policyConfigFile)
 
-func main() {
-	colog.SetDefaultLevel(colog.LInfo)
-	colog.SetMinLevel(colog.LInfo)
-	colog.SetFormatter(&colog.StdFormatter{
-		Colors: true,
-		Flag:   log.Ldate | log.Ltime | log.Lshortfile,
-	})
-	colog.Register()
 	level := StringToLevel(os.Getenv("LOG_LEVEL"))
-	log.Print("Log level was set to ", strings.ToUpper(level.String()))
-	colog.SetMinLevel(level)
+	SetMinLevel(level)
+	NewLogger().Info("log level set", "level", level.String())
 
 	go informer.Run(wait.NeverStop)
-	cache.WaitForCacheSync(wait.NeverStop, informer.HasSynced)
+	go pendingInformer.Run(wait.NeverStop)
+	go nodeInformer.Run(wait.NeverStop)
+	cache.WaitForCacheSync(wait.NeverStop, informer.HasSynced, pendingInformer.HasSynced, nodeInformer.HasSynced)
+	cacheSynced.Set(1)
+	go recordIndexerSizes()
 
 	router := httprouter.New()
 	AddVersion(router)
-
-	predicates := []Predicate{TruePredicate}
-	for _, p := range predicates {
-		AddPredicate(router, p)
+	AddMetrics(router)
+
+	// Every registered predicate/prioritizer gets a route; whether it
+	// actually runs for a given request is decided per-call by the policy
+	// gate below, so enabling/disabling one via SIGHUP never requires
+	// re-registering routes.
+	for _, p := range predicateRegistry {
+		AddPredicate(router, policyGatedPredicate(p))
 	}
-
-	priorities := []Prioritize{GroupPriority}
-	for _, p := range priorities {
-		AddPrioritize(router, p)
+	for _, p := range prioritizeRegistry {
+		AddPrioritize(router, policyGatedPrioritize(p))
 	}
 
-	AddBind(router, NoBind)
+	AddBind(router, PodGroupBind)
+	AddPreempt(router, LowestPriorityFirstPreempt)
 
 	log.Print("info: server starting on the port :80")
 	if err := http.ListenAndServe(":80", router); err != nil {
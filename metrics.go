@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsPath = "/metrics"
+
+const metricsNamespace = "scheduler_extender"
+
+var (
+	// requestDuration times every extender HTTP call, labeled by verb
+	// (filter/prioritize/bind/preempt) and the registered extension name,
+	// so a slow PodGroupGang call shows up separately from a slow
+	// LeastRequestedPriority call.
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "request_duration_seconds",
+		Help:      "Latency of extender HTTP requests, by verb and extension name.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"verb", "name"})
+
+	requestTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "requests_total",
+		Help:      "Total extender HTTP requests, by verb and extension name.",
+	}, []string{"verb", "name"})
+
+	requestErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "request_errors_total",
+		Help:      "Total extender HTTP requests that returned an error, by verb and extension name.",
+	}, []string{"verb", "name"})
+
+	cacheSynced = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "cache_synced",
+		Help:      "1 once all informer caches have completed their initial sync, 0 until then.",
+	})
+
+	indexerSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "indexer_size",
+		Help:      "Number of objects currently held by each informer indexer.",
+	}, []string{"indexer"})
+
+	nodeScores = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "node_scores",
+		Help:      "Computed HostPriority scores, by prioritizer and the node's \"group\" label.",
+		Buckets:   []float64{0, 10, 100, 1000, 5000, 10000},
+	}, []string{"prioritizer", "node_group"})
+
+	waitingGroups = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "bind_waiting_groups",
+		Help:      "Number of PodGroups currently parked in the bind permit area.",
+	}, func() float64 { return float64(waitingGroupCount()) })
+)
+
+func init() {
+	prometheus.MustRegister(requestDuration, requestTotal, requestErrorsTotal, cacheSynced, indexerSize, nodeScores, waitingGroups)
+}
+
+// AddMetrics exposes the Prometheus registry on metricsPath.
+func AddMetrics(router *httprouter.Router) {
+	handler := promhttp.Handler()
+	router.Handler(http.MethodGet, metricsPath, handler)
+}
+
+// observeRequest records one extender HTTP call's latency and outcome. It
+// is called from every handler in routes.go (predicateHandler,
+// prioritizeHandler, bindHandler, preemptHandler) so registering a new
+// Predicate/Prioritize/Bind/Preempt automatically gets measured without
+// the implementation touching metrics itself.
+func observeRequest(verb, name string, start time.Time, err error) {
+	elapsed := time.Since(start).Seconds()
+	requestDuration.WithLabelValues(verb, name).Observe(elapsed)
+	requestTotal.WithLabelValues(verb, name).Inc()
+	if err != nil {
+		requestErrorsTotal.WithLabelValues(verb, name).Inc()
+	}
+}
+
+// recordIndexerSizes is run periodically so indexerSize stays current
+// without every informer event handler having to update a gauge itself.
+func recordIndexerSizes() {
+	for range time.Tick(15 * time.Second) {
+		indexerSize.WithLabelValues("pods").Set(float64(len(indexer.List())))
+		indexerSize.WithLabelValues("pending_pods").Set(float64(len(pendingIndexer.List())))
+		indexerSize.WithLabelValues("nodes").Set(float64(len(nodeIndexer.List())))
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"k8s.io/api/core/v1"
+)
+
+// nodeMatchesSelectorTerm reports whether node satisfies every expression in
+// term (expressions within a term are ANDed, matching the upstream
+// scheduler's NodeSelectorTerm semantics).
+func nodeMatchesSelectorTerm(node v1.Node, term v1.NodeSelectorTerm) bool {
+	for _, expr := range term.MatchExpressions {
+		if !nodeMatchesSelectorRequirement(node, expr) {
+			return false
+		}
+	}
+	return true
+}
+
+func nodeMatchesSelectorRequirement(node v1.Node, req v1.NodeSelectorRequirement) bool {
+	value, has := node.Labels[req.Key]
+	switch req.Operator {
+	case v1.NodeSelectorOpExists:
+		return has
+	case v1.NodeSelectorOpDoesNotExist:
+		return !has
+	case v1.NodeSelectorOpIn:
+		return has && containsString(req.Values, value)
+	case v1.NodeSelectorOpNotIn:
+		return !has || !containsString(req.Values, value)
+	case v1.NodeSelectorOpGt, v1.NodeSelectorOpLt:
+		if !has || len(req.Values) != 1 {
+			return false
+		}
+		nodeNum, err1 := strconv.Atoi(value)
+		reqNum, err2 := strconv.Atoi(req.Values[0])
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		if req.Operator == v1.NodeSelectorOpGt {
+			return nodeNum > reqNum
+		}
+		return nodeNum < reqNum
+	default:
+		return false
+	}
+}
+
+func containsString(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
+
+// NodeAffinityPredicate enforces a pod's required node affinity
+// (spec.affinity.nodeAffinity.requiredDuringSchedulingIgnoredDuringExecution),
+// the only node affinity rule the scheduler must not violate; preferred
+// terms are a prioritizer's job, not a predicate's. A pod with no required
+// node affinity always fits.
+var NodeAffinityPredicate = Predicate{
+	Name: "node_affinity",
+	Func: func(ctx context.Context, pod v1.Pod, node v1.Node) (bool, error) {
+		if pod.Spec.Affinity == nil || pod.Spec.Affinity.NodeAffinity == nil {
+			return true, nil
+		}
+		required := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		if required == nil || len(required.NodeSelectorTerms) == 0 {
+			return true, nil
+		}
+		for _, term := range required.NodeSelectorTerms {
+			if nodeMatchesSelectorTerm(node, term) {
+				return true, nil
+			}
+		}
+		return false, nil
+	},
+}
+
+// tolerationMatchesTaint reports whether toleration tolerates taint, using
+// the same matching rules as the upstream scheduler: an empty key or
+// effect matches anything, and the operator defaults to Equal.
+func tolerationMatchesTaint(toleration v1.Toleration, taint v1.Taint) bool {
+	if toleration.Effect != "" && toleration.Effect != taint.Effect {
+		return false
+	}
+	if toleration.Key != "" && toleration.Key != taint.Key {
+		return false
+	}
+	switch toleration.Operator {
+	case v1.TolerationOpExists:
+		return true
+	case v1.TolerationOpEqual, "":
+		return toleration.Value == taint.Value
+	default:
+		return false
+	}
+}
+
+// TaintTolerationPredicate rejects nodes carrying an untolerated taint with
+// effect NoSchedule or NoExecute; PreferNoSchedule taints only influence
+// scoring and are ignored here.
+var TaintTolerationPredicate = Predicate{
+	Name: "taint_toleration",
+	Func: func(ctx context.Context, pod v1.Pod, node v1.Node) (bool, error) {
+		for _, taint := range node.Spec.Taints {
+			if taint.Effect != v1.TaintEffectNoSchedule && taint.Effect != v1.TaintEffectNoExecute {
+				continue
+			}
+			tolerated := false
+			for _, toleration := range pod.Spec.Tolerations {
+				if tolerationMatchesTaint(toleration, taint) {
+					tolerated = true
+					break
+				}
+			}
+			if !tolerated {
+				return false, nil
+			}
+		}
+		return true, nil
+	},
+}
+
+func init() {
+	RegisterPredicate(NodeAffinityPredicate)
+	RegisterPredicate(TaintTolerationPredicate)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	v1 "k8s.io/api/core/v1"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var policyConfigFile = flag.String("policy-config-file", "", "path to a JSON/YAML PolicyConfig listing enabled predicates, prioritizers and their weights; also settable via the POLICY_CONFIG env var. Reloaded on SIGHUP.")
+
+// PriorityConfig enables one registered Prioritize by name and scales its
+// returned scores by Weight (default 1) before they go back to the
+// scheduler, mirroring upstream scheduler policy files.
+type PriorityConfig struct {
+	Name   string `json:"name"`
+	Weight int64  `json:"weight,omitempty"`
+}
+
+// Tunables holds the handful of per-extender knobs that used to be
+// hardcoded, such as the node label value GroupPriority treats specially.
+type Tunables struct {
+	ScaleGroupLabelValue string `json:"scaleGroupLabelValue,omitempty"`
+}
+
+// PolicyConfig is the operator-facing policy document: which registered
+// predicates/prioritizers are enabled, their weights, and extender-wide
+// tunables. Anything not listed here falls back to the defaults below.
+type PolicyConfig struct {
+	Predicates []string         `json:"predicates"`
+	Priorities []PriorityConfig `json:"priorities"`
+	Tunables   Tunables         `json:"tunables,omitempty"`
+}
+
+func defaultPolicy() PolicyConfig {
+	return PolicyConfig{
+		Predicates: []string{TruePredicate.Name, PodGroupGang.Name, NodeAffinityPredicate.Name, TaintTolerationPredicate.Name},
+		Priorities: []PriorityConfig{
+			{Name: GroupPriority.Name, Weight: 1},
+			{Name: PodGroupAffinityPriority.Name, Weight: 1},
+			{Name: BalancedResourceAllocationPriority.Name, Weight: 1},
+			{Name: LeastRequestedPriority.Name, Weight: 1},
+		},
+		Tunables: Tunables{ScaleGroupLabelValue: "Scale"},
+	}
+}
+
+// activePolicy is swapped atomically on load/reload so in-flight requests
+// always see a consistent policy.
+var activePolicy atomic.Value // holds PolicyConfig
+
+func init() {
+	activePolicy.Store(defaultPolicy())
+}
+
+func currentPolicy() PolicyConfig {
+	return activePolicy.Load().(PolicyConfig)
+}
+
+// loadPolicy reads path (falling back to the POLICY_CONFIG env var, then
+// the built-in defaults) and stores the result as the active policy.
+func loadPolicy(path string) error {
+	if path == "" {
+		path = os.Getenv("POLICY_CONFIG")
+	}
+	if path == "" {
+		activePolicy.Store(defaultPolicy())
+		return nil
+	}
+
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	policy := defaultPolicy()
+	if err := yaml.Unmarshal(raw, &policy); err != nil {
+		return err
+	}
+	activePolicy.Store(policy)
+	log.Printf("loaded policy config from %s: %d predicates, %d priorities\n", path, len(policy.Predicates), len(policy.Priorities))
+	return nil
+}
+
+// watchPolicyReload reloads the policy config from path whenever the
+// process receives SIGHUP, so operators can retune enabled
+// predicates/priorities without a restart.
+func watchPolicyReload(path string) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go func() {
+		for range sighup {
+			if err := loadPolicy(path); err != nil {
+				log.Printf("error reloading policy config: %v\n", err)
+			}
+		}
+	}()
+}
+
+func predicateEnabled(name string) bool {
+	for _, n := range currentPolicy().Predicates {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// scaleGroupLabelValue returns the node "group" label value that
+// GroupPriority treats specially, falling back to "Scale" if the active
+// policy doesn't set tunables.scaleGroupLabelValue.
+func scaleGroupLabelValue() string {
+	if v := currentPolicy().Tunables.ScaleGroupLabelValue; v != "" {
+		return v
+	}
+	return "Scale"
+}
+
+func priorityWeight(name string) (int64, bool) {
+	for _, p := range currentPolicy().Priorities {
+		if p.Name == name {
+			if p.Weight == 0 {
+				return 1, true
+			}
+			return p.Weight, true
+		}
+	}
+	return 0, false
+}
+
+// registry is the set of Predicate/Prioritize implementations the binary
+// ships with, keyed by name, so the policy config can enable them
+// declaratively instead of main() hardcoding a slice.
+var (
+	predicateRegistry = map[string]Predicate{}
+	prioritizeRegistry = map[string]Prioritize{}
+)
+
+func RegisterPredicate(p Predicate) {
+	predicateRegistry[p.Name] = p
+}
+
+func RegisterPrioritize(p Prioritize) {
+	prioritizeRegistry[p.Name] = p
+}
+
+// policyGatedPredicate wraps p so it only runs when the active policy
+// enables it by name; otherwise it passes every node, so disabling a
+// predicate via SIGHUP reload never requires restarting the router.
+func policyGatedPredicate(p Predicate) Predicate {
+	return Predicate{
+		Name: p.Name,
+		Func: func(ctx context.Context, pod v1.Pod, node v1.Node) (bool, error) {
+			if !predicateEnabled(p.Name) {
+				return true, nil
+			}
+			return p.Func(ctx, pod, node)
+		},
+	}
+}
+
+// policyGatedPrioritize wraps p so it only scores when the active policy
+// enables it, scaling its HostPriority scores by the configured weight.
+func policyGatedPrioritize(p Prioritize) Prioritize {
+	return Prioritize{
+		Name: p.Name,
+		Func: func(ctx context.Context, pod v1.Pod, nodes []v1.Node) (*schedulerapi.HostPriorityList, error) {
+			weight, enabled := priorityWeight(p.Name)
+			if !enabled {
+				list := make(schedulerapi.HostPriorityList, len(nodes))
+				for i, node := range nodes {
+					list[i] = schedulerapi.HostPriority{Host: node.Name, Score: 0}
+				}
+				return &list, nil
+			}
+			list, err := p.Func(ctx, pod, nodes)
+			if err != nil {
+				return nil, err
+			}
+			for i := range *list {
+				(*list)[i].Score *= weight
+			}
+			return list, nil
+		},
+	}
+}
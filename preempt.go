@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
+)
+
+// podSafeToEvict reports whether pod can be preempted without violating any
+// PodDisruptionBudget that currently selects it. It fails closed: a PDB
+// lookup error, or a matching PDB with no disruptions left, makes the pod
+// ineligible.
+func podSafeToEvict(ctx context.Context, pod *v1.Pod) bool {
+	pdbs, err := clientSet.PolicyV1beta1().PodDisruptionBudgets(pod.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		LoggerFrom(ctx).Error(err, "failed to list PodDisruptionBudgets", "namespace", pod.Namespace)
+		return false
+	}
+
+	for _, pdb := range pdbs.Items {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		if pdb.Status.DisruptionsAllowed < 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func podPriority(pod *v1.Pod) int32 {
+	if pod.Spec.Priority != nil {
+		return *pod.Spec.Priority
+	}
+	return 0
+}
+
+func podResourceRequests(pod *v1.Pod) (cpu int64, mem int64) {
+	for _, container := range pod.Spec.Containers {
+		cpu += container.Resources.Requests.Cpu().MilliValue()
+		mem += container.Resources.Requests.Memory().Value()
+	}
+	return cpu, mem
+}
+
+// selectVictims greedily picks the smallest prefix of lowest-priority,
+// PDB-safe pods from candidates whose combined resources cover needCPU and
+// needMem. A candidate is only evictable if it is both PDB-safe and
+// strictly lower priority than preemptor; candidates are never evicted to
+// make room for an equal-or-lower-priority pod. It returns nil if the
+// candidates can never cover the request.
+func selectVictims(ctx context.Context, preemptor *v1.Pod, candidates []*v1.Pod, needCPU, needMem int64) []*v1.Pod {
+	preemptorPriority := podPriority(preemptor)
+	evictable := make([]*v1.Pod, 0, len(candidates))
+	for _, pod := range candidates {
+		if podPriority(pod) < preemptorPriority && podSafeToEvict(ctx, pod) {
+			evictable = append(evictable, pod)
+		}
+	}
+	sort.Slice(evictable, func(i, j int) bool {
+		return podPriority(evictable[i]) < podPriority(evictable[j])
+	})
+
+	// Non-nil even when empty, so a request that needs no evictions is not
+	// mistaken for one that can never be covered.
+	chosen := []*v1.Pod{}
+	var freedCPU, freedMem int64
+	for _, pod := range evictable {
+		if freedCPU >= needCPU && freedMem >= needMem {
+			break
+		}
+		cpu, mem := podResourceRequests(pod)
+		freedCPU += cpu
+		freedMem += mem
+		chosen = append(chosen, pod)
+	}
+	if freedCPU < needCPU || freedMem < needMem {
+		return nil
+	}
+	return chosen
+}
+
+// LowestPriorityFirstPreempt implements the extender preemption protocol: for
+// each node's candidate victims, evict the fewest, lowest-priority,
+// PDB-safe pods needed to free enough room for pod. Nodes that cannot be
+// made to fit even after evicting every safe candidate are dropped from
+// the result, matching how the native scheduler's preemption plugin
+// narrows candidate nodes.
+var LowestPriorityFirstPreempt = Preempt{
+	Name: "lowest_priority_first",
+	Func: func(ctx context.Context, pod v1.Pod, nodeNameToVictims map[string]*schedulerapi.Victims) (map[string]*schedulerapi.MetaVictims, error) {
+		needCPU, needMem := podResourceRequests(&pod)
+
+		result := map[string]*schedulerapi.MetaVictims{}
+		for node, victims := range nodeNameToVictims {
+			chosen := selectVictims(ctx, &pod, victims.Pods, needCPU, needMem)
+			if chosen == nil {
+				continue
+			}
+
+			metaPods := make([]*schedulerapi.MetaPod, 0, len(chosen))
+			for _, victim := range chosen {
+				metaPods = append(metaPods, &schedulerapi.MetaPod{UID: string(victim.UID)})
+			}
+			result[node] = &schedulerapi.MetaVictims{
+				Pods: metaPods,
+				// chosen only ever contains pods selectVictims already
+				// confirmed PDB-safe, unlike victims.Pods (the scheduler's
+				// original, unfiltered candidate list), so the violation
+				// count for what we're actually returning is always 0.
+				NumPDBViolations: 0,
+			}
+		}
+		return result, nil
+	},
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+
+	"k8s.io/api/core/v1"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
+)
+
+const maxPriority = 10
+
+// fractionOfCapacity returns requested/allocatable, or 0 if allocatable is
+// not yet known (node never observed by the node informer).
+func fractionOfCapacity(requested, allocatable int64) float64 {
+	if allocatable <= 0 {
+		return 0
+	}
+	return float64(requested) / float64(allocatable)
+}
+
+// LeastRequestedPriority favors nodes with the most free capacity, spreading
+// load across the cluster. It reads from the shared snapshot so it costs a
+// map lookup per node rather than an indexer scan.
+var LeastRequestedPriority = Prioritize{
+	Name: "least_requested",
+	Func: func(ctx context.Context, _ v1.Pod, nodes []v1.Node) (*schedulerapi.HostPriorityList, error) {
+		snapshot := nodeLister.Snapshot()
+		priorityList := make(schedulerapi.HostPriorityList, len(nodes))
+
+		for i, node := range nodes {
+			info, ok := snapshot.NodeInfo(node.Name)
+			if !ok {
+				priorityList[i] = schedulerapi.HostPriority{Host: node.Name, Score: maxPriority * 100}
+				continue
+			}
+			cpuFraction := fractionOfCapacity(info.RequestedCPU, info.AllocatableCPU)
+			memFraction := fractionOfCapacity(info.RequestedMem, info.AllocatableMem)
+			score := (2 - cpuFraction - memFraction) / 2 * maxPriority * 100
+			priorityList[i] = schedulerapi.HostPriority{Host: node.Name, Score: int64(score)}
+		}
+		return &priorityList, nil
+	},
+}
+
+// BalancedResourceAllocationPriority favors nodes whose CPU and memory
+// utilization are closest to each other, avoiding nodes that are lopsided
+// (e.g. CPU-starved but memory-idle). Utilization is the node's current
+// requested/allocatable from the snapshot; the incoming pod's own requests
+// are not added.
+var BalancedResourceAllocationPriority = Prioritize{
+	Name: "balanced_resource_allocation",
+	Func: func(ctx context.Context, _ v1.Pod, nodes []v1.Node) (*schedulerapi.HostPriorityList, error) {
+		snapshot := nodeLister.Snapshot()
+		priorityList := make(schedulerapi.HostPriorityList, len(nodes))
+
+		for i, node := range nodes {
+			info, ok := snapshot.NodeInfo(node.Name)
+			if !ok {
+				priorityList[i] = schedulerapi.HostPriority{Host: node.Name, Score: 0}
+				continue
+			}
+			cpuFraction := fractionOfCapacity(info.RequestedCPU, info.AllocatableCPU)
+			memFraction := fractionOfCapacity(info.RequestedMem, info.AllocatableMem)
+
+			diff := cpuFraction - memFraction
+			if diff < 0 {
+				diff = -diff
+			}
+			score := (1 - diff) * maxPriority * 100
+			priorityList[i] = schedulerapi.HostPriority{Host: node.Name, Score: int64(score)}
+		}
+		return &priorityList, nil
+	},
+}
+
+// MostRequestedPriority favors nodes with the least free capacity, the
+// inverse of LeastRequestedPriority. Useful for bin-packing workloads onto
+// as few nodes as possible, e.g. to let a cluster autoscaler drain and
+// remove the emptiest nodes.
+var MostRequestedPriority = Prioritize{
+	Name: "most_requested",
+	Func: func(ctx context.Context, _ v1.Pod, nodes []v1.Node) (*schedulerapi.HostPriorityList, error) {
+		snapshot := nodeLister.Snapshot()
+		priorityList := make(schedulerapi.HostPriorityList, len(nodes))
+
+		for i, node := range nodes {
+			info, ok := snapshot.NodeInfo(node.Name)
+			if !ok {
+				priorityList[i] = schedulerapi.HostPriority{Host: node.Name, Score: 0}
+				continue
+			}
+			cpuFraction := fractionOfCapacity(info.RequestedCPU, info.AllocatableCPU)
+			memFraction := fractionOfCapacity(info.RequestedMem, info.AllocatableMem)
+			score := (cpuFraction + memFraction) / 2 * maxPriority * 100
+			priorityList[i] = schedulerapi.HostPriority{Host: node.Name, Score: int64(score)}
+		}
+		return &priorityList, nil
+	},
+}
+
+func init() {
+	RegisterPrioritize(LeastRequestedPriority)
+	RegisterPrioritize(MostRequestedPriority)
+	RegisterPrioritize(BalancedResourceAllocationPriority)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
+)
+
+const (
+	verbFilter     = "filter"
+	verbPrioritize = "prioritize"
+	verbBind       = "bind"
+	verbPreempt    = "preempt"
+)
+
+// Predicate is a filter extension: it decides whether pod is allowed to run
+// on node. ctx carries a Logger decorated with the request's correlation
+// id and pod identity (see requestContext and withPod) so implementations can log
+// without re-deriving that context themselves.
+type Predicate struct {
+	Name string
+	Func func(ctx context.Context, pod v1.Pod, node v1.Node) (bool, error)
+}
+
+// Prioritize is a scoring extension: it ranks the candidate nodes for pod.
+type Prioritize struct {
+	Name string
+	Func func(ctx context.Context, pod v1.Pod, nodes []v1.Node) (*schedulerapi.HostPriorityList, error)
+}
+
+// Bind commits the scheduling decision made for a pod.
+type Bind struct {
+	Func func(ctx context.Context, podName string, podNamespace string, podUID types.UID, node string) error
+}
+
+// Preempt decides, for each node's set of candidate victims, which ones
+// actually need to make way for pod. Returning a node from the result
+// means "pod can be scheduled here once these victims are gone"; omitting
+// a node means preemption cannot make it schedulable.
+type Preempt struct {
+	Name string
+	Func func(ctx context.Context, pod v1.Pod, nodeNameToVictims map[string]*schedulerapi.Victims) (map[string]*schedulerapi.MetaVictims, error)
+}
+
+// requestContext builds the context.Context threaded into every
+// Predicate/Prioritize/Bind call for one inbound extender request: a
+// Logger decorated with the request-id (taken from the X-Request-Id
+// header, or minted if absent) and the request path, so every log line
+// for one scheduling cycle can be grepped together. The pod's
+// namespace/name/UID are added afterwards, once the request body has been
+// decoded (withPod, or directly in bindHandler).
+func requestContext(r *http.Request) context.Context {
+	requestID := r.Header.Get(requestIDHeader)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	logger := NewLogger().WithValues("requestId", requestID, "verb", r.URL.Path)
+	return WithLogger(r.Context(), logger)
+}
+
+func withPod(ctx context.Context, pod *v1.Pod) context.Context {
+	if pod == nil {
+		return ctx
+	}
+	logger := LoggerFrom(ctx).WithValues("podNamespace", pod.Namespace, "podName", pod.Name, "podUID", pod.UID)
+	return WithLogger(ctx, logger)
+}
+
+func AddVersion(router *httprouter.Router) {
+	router.GET(versionPath, func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		fmt.Fprint(w, version)
+	})
+}
+
+func AddPredicate(router *httprouter.Router, predicate Predicate) {
+	router.POST(predicatesPrefix+"/"+predicate.Name, predicateHandler(predicate))
+}
+
+func AddPrioritize(router *httprouter.Router, prioritize Prioritize) {
+	router.POST(prioritiesPrefix+"/"+prioritize.Name, prioritizeHandler(prioritize))
+}
+
+func AddBind(router *httprouter.Router, bind Bind) {
+	router.POST(bindPath, bindHandler(bind))
+}
+
+func AddPreempt(router *httprouter.Router, preempt Preempt) {
+	router.POST(preemptionPath, preemptHandler(preempt))
+}
+
+// predicateHandler evaluates predicate once per candidate node (rather than
+// once for the whole request) so node-specific predicates such as
+// NodeAffinityPredicate and TaintTolerationPredicate see the real node
+// instead of an empty v1.Node{}. Node-agnostic predicates (TruePredicate,
+// PodGroupGang) just ignore the node argument and get the same result as
+// before.
+func predicateHandler(predicate Predicate) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		start := time.Now()
+		ctx := requestContext(r)
+		var args schedulerapi.ExtenderArgs
+		if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx = withPod(ctx, args.Pod)
+
+		var candidates []v1.Node
+		if args.Nodes != nil {
+			candidates = args.Nodes.Items
+		}
+
+		var err error
+		result := schedulerapi.ExtenderFilterResult{}
+		failedNodes := schedulerapi.FailedNodesMap{}
+		fit := make([]v1.Node, 0, len(candidates))
+		for _, node := range candidates {
+			var ok bool
+			ok, err = predicate.Func(ctx, *args.Pod, node)
+			if err != nil {
+				break
+			}
+			if ok {
+				fit = append(fit, node)
+			} else {
+				failedNodes[node.Name] = fmt.Sprintf("node did not satisfy predicate %q", predicate.Name)
+			}
+		}
+		defer observeRequest(verbFilter, predicate.Name, start, err)
+
+		if err != nil {
+			LoggerFrom(ctx).Error(err, "predicate failed", "predicate", predicate.Name)
+			result.Error = err.Error()
+		} else {
+			result.Nodes = &v1.NodeList{Items: fit}
+			result.FailedNodes = failedNodes
+		}
+
+		writeJSON(ctx, w, &result)
+	}
+}
+
+func prioritizeHandler(prioritize Prioritize) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		start := time.Now()
+		ctx := requestContext(r)
+		var args schedulerapi.ExtenderArgs
+		if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx = withPod(ctx, args.Pod)
+
+		var nodes []v1.Node
+		if args.Nodes != nil {
+			nodes = args.Nodes.Items
+		}
+
+		priorities, err := prioritize.Func(ctx, *args.Pod, nodes)
+		defer observeRequest(verbPrioritize, prioritize.Name, start, err)
+		if err != nil {
+			LoggerFrom(ctx).Error(err, "prioritize failed", "prioritizer", prioritize.Name)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		recordNodeScores(prioritize.Name, nodes, *priorities)
+		writeJSON(ctx, w, priorities)
+	}
+}
+
+// recordNodeScores observes each returned HostPriority score against the
+// node's "group" label so operators can see, per prioritizer, how scores
+// are distributed across e.g. the "Scale" node group vs. everything else.
+func recordNodeScores(prioritizer string, nodes []v1.Node, scores schedulerapi.HostPriorityList) {
+	byHost := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		byHost[node.Name] = node.Labels["group"]
+	}
+	for _, score := range scores {
+		nodeScores.WithLabelValues(prioritizer, byHost[score.Host]).Observe(float64(score.Score))
+	}
+}
+
+func bindHandler(bind Bind) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		start := time.Now()
+		ctx := requestContext(r)
+		var args schedulerapi.ExtenderBindingArgs
+		if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logger := LoggerFrom(ctx).WithValues("podNamespace", args.PodNamespace, "podName", args.PodName, "podUID", args.PodUID, "node", args.Node)
+		ctx = WithLogger(ctx, logger)
+
+		result := schedulerapi.ExtenderBindingResult{}
+		err := bind.Func(ctx, args.PodName, args.PodNamespace, args.PodUID, args.Node)
+		defer observeRequest(verbBind, "bind", start, err)
+		if err != nil {
+			logger.Error(err, "bind failed")
+			result.Error = err.Error()
+		}
+
+		writeJSON(ctx, w, &result)
+	}
+}
+
+func preemptHandler(preempt Preempt) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		start := time.Now()
+		ctx := requestContext(r)
+		var args schedulerapi.ExtenderPreemptionArgs
+		if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx = withPod(ctx, args.Pod)
+
+		victims, err := preempt.Func(ctx, *args.Pod, args.NodeNameToVictims)
+		defer observeRequest(verbPreempt, preempt.Name, start, err)
+		if err != nil {
+			LoggerFrom(ctx).Error(err, "preempt failed", "preempt", preempt.Name)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(ctx, w, &schedulerapi.ExtenderPreemptionResult{NodeNameToMetaVictims: victims})
+	}
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		LoggerFrom(ctx).Error(err, "failed to encode response")
+	}
+}